@@ -8,7 +8,11 @@ import (
 	"net"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 /**********************************
@@ -18,15 +22,131 @@ import (
 type traefik_block_paths struct {
 	next               http.Handler
 	name               string
-	regexps 		   []*regexp.Regexp
+	rules              []*compiledRule
+	allowLocalRequests bool
+	privateRanges      []*net.IPNet
+	trustedProxies     []*net.IPNet
 	silentStartUp      bool
 	statusCode         int
+	banEnabled         bool
+	banMaxHits         int
+	banWindow          time.Duration
+	banDuration        time.Duration
+	banStatusCode      int
+	bans               map[string]*banEntry
+	banMutex           sync.Mutex
+	metricsEnabled     bool
+	metricsPath        string
+	metricsIncludeHost bool
+	allowedCount       int64
+	banEventsCount     int64
+}
+
+// banEntry tracks the block-hit timestamps and, once MaxHits is reached inside Window,
+// the time until which the client is banned.
+type banEntry struct {
+	hits        []time.Time
+	bannedUntil time.Time
+}
+
+// compiledRule is the runtime form of a Rule: the regex is compiled and the method
+// list is a lookup set instead of a slice.
+type compiledRule struct {
+	regex        *regexp.Regexp
+	methods      map[string]struct{}
+	queryRegex   *regexp.Regexp
+	headerRegex  map[string]*regexp.Regexp
+	minBodyBytes int64
+	statusCode   int
+	responseBody string
+	contentType  string
+	redirectTo   string
+	permanent    bool
+
+	// Metrics labels, precomputed here since Yaegi plugins cannot pull in
+	// prometheus/client_golang; see writeBlockedSamples for the exporter.
+	metricRegexLabel  string
+	metricStatusLabel string
+	blockedCount      int64
+	blockedByHost     sync.Map // host (string) -> *int64, populated only when host labels are enabled
+}
+
+// Matcher describes what a Rule matches against. PathRegex and Methods are the base
+// criteria; QueryRegex, HeaderRegex and MinBodyBytes narrow the match further and are
+// combined with AND semantics - every populated field must match for the rule to apply.
+// This lets one Rule express what would otherwise be a stack of ad-hoc path/header/query
+// middlewares, e.g. any path with a "?XDEBUG_SESSION_START=" query, a "User-Agent: ZmEu"
+// header, or a large POST body to "/api/*".
+type Matcher struct {
+	PathRegex    string            `yaml:"pathRegex"`
+	Methods      []string          `yaml:"methods,omitempty"`
+	QueryRegex   string            `yaml:"queryRegex,omitempty"`
+	HeaderRegex  map[string]string `yaml:"headerRegex,omitempty"`
+	MinBodyBytes int64             `yaml:"minBodyBytes,omitempty"`
+}
+
+// Rule describes a single blocking rule: what to match (Matcher) and how to respond.
+// StatusCode falls back to the top-level Config.StatusCode when zero.
+// When RedirectTo is set, the rule responds with a redirect instead of ResponseBody/StatusCode;
+// Permanent picks 301 (classic, cacheable) over the default 308 (preserves the request method).
+type Rule struct {
+	Matcher      `yaml:",inline"`
+	StatusCode   int    `yaml:"statusCode,omitempty"`
+	ResponseBody string `yaml:"responseBody,omitempty"`
+	ContentType  string `yaml:"contentType,omitempty"`
+	RedirectTo   string `yaml:"redirectTo,omitempty"`
+	Permanent    bool   `yaml:"permanent,omitempty"`
 }
 
 type Config struct {
-	Regex              []string `yaml:"regex,omitempty"`
-	SilentStartUp      bool     `yaml:"silentStartUp"`
-	StatusCode         int      `yaml:"statusCode"`
+	AllowLocalRequests bool          `yaml:"allowLocalRequests"`
+	Ban                BanConfig     `yaml:"ban,omitempty"`
+	Metrics            MetricsConfig `yaml:"metrics,omitempty"`
+	PrivateRanges      []string      `yaml:"privateRanges,omitempty"`
+	Regex              []string      `yaml:"regex,omitempty"`
+	Rules              []Rule        `yaml:"rules,omitempty"`
+	SilentStartUp      bool          `yaml:"silentStartUp"`
+	StatusCode         int           `yaml:"statusCode"`
+	TrustedProxies     []string      `yaml:"trustedProxies,omitempty"`
+}
+
+// MetricsConfig exposes a self-contained Prometheus text-format exporter at Path
+// (default "/__blockpaths/metrics"), since Yaegi plugins cannot import prometheus/client_golang.
+// IncludeHostLabel adds a "host" label to blockpaths_requests_blocked_total; leave it disabled
+// unless the deployment has a bounded set of hosts, since each distinct host grows the series count.
+type MetricsConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	Path             string `yaml:"path,omitempty"`
+	IncludeHostLabel bool   `yaml:"includeHostLabel,omitempty"`
+}
+
+// BanConfig enables ephemeral, fail2ban-style IP banning: once a client reaches MaxHits
+// blocked requests inside Window, it is banned until Duration has passed. StatusCode
+// falls back to the top-level Config.StatusCode when zero. Opt-in via Enabled so existing
+// users aren't surprised by the added state.
+type BanConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	MaxHits    int           `yaml:"maxHits,omitempty"`
+	Window     time.Duration `yaml:"window,omitempty"`
+	Duration   time.Duration `yaml:"duration,omitempty"`
+	StatusCode int           `yaml:"statusCode,omitempty"`
+}
+
+// defaultMetricsPath is the metrics scrape endpoint used when Metrics.Path is empty.
+const defaultMetricsPath = "/__blockpaths/metrics"
+
+// defaultPrivateRanges is the explicit, auditable set of ranges considered "local"
+// when no PrivateRanges are configured: RFC1918, CGNAT, loopback, link-local and ULA.
+var defaultPrivateRanges = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"::1/128",
+	"fe80::/10",
+	"fc00::/7",
 }
 
 /**********************************
@@ -36,62 +156,165 @@ type Config struct {
 // CreateConfig creates the default plugin configuration.
 func CreateConfig() *Config {
 	return &Config{
+		AllowLocalRequests: true,
 		SilentStartUp:      true,
-		StatusCode:			403, // https://cs.opensource.google/go/go/+/refs/tags/go1.21.4:src/net/http/status.go
+		StatusCode:         403, // https://cs.opensource.google/go/go/+/refs/tags/go1.21.4:src/net/http/status.go
 	}
 }
 
 // New creates a new plugin.
 // Returns the configured BlockPaths plugin object.
 func New(ctx context.Context, next http.Handler, config *Config, name string) (http.Handler, error) {
-	if len(config.Regex) == 0 {
-		return nil, fmt.Errorf("the regex list is empty")
+	rules := synthesizeRules(config)
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("the rule list is empty")
 	}
 
 	if !config.SilentStartUp {
-		log.Println("Regex list: ", config.Regex)
+		log.Println("Rule count: ", len(rules))
 		log.Println("StatusCode: ", config.StatusCode)
+		log.Println("AllowLocalRequests: ", config.AllowLocalRequests)
+		log.Println("TrustedProxies: ", config.TrustedProxies)
 	}
 
-	regexps := make([]*regexp.Regexp, len(config.Regex))
+	compiledRules := make([]*compiledRule, len(rules))
 
-	for index, regex := range config.Regex {
-		compiledRegex, compileError := regexp.Compile(regex)
+	for index, rule := range rules {
+		compiledRegex, compileError := regexp.Compile(rule.PathRegex)
 		if compileError != nil {
-			return nil, fmt.Errorf("error compiling regex %q: %w", regex, compileError)
+			return nil, fmt.Errorf("error compiling regex %q: %w", rule.PathRegex, compileError)
+		}
+
+		statusCode := rule.StatusCode
+		if statusCode == 0 {
+			statusCode = config.StatusCode
+		}
+
+		methods := make(map[string]struct{}, len(rule.Methods))
+		for _, method := range rule.Methods {
+			methods[strings.ToUpper(method)] = struct{}{}
+		}
+
+		var queryRegex *regexp.Regexp
+		if rule.QueryRegex != "" {
+			queryRegex, compileError = regexp.Compile(rule.QueryRegex)
+			if compileError != nil {
+				return nil, fmt.Errorf("error compiling queryRegex %q: %w", rule.QueryRegex, compileError)
+			}
 		}
 
-		regexps[index] = compiledRegex
+		headerRegex := make(map[string]*regexp.Regexp, len(rule.HeaderRegex))
+		for headerName, pattern := range rule.HeaderRegex {
+			compiledHeaderRegex, compileError := regexp.Compile(pattern)
+			if compileError != nil {
+				return nil, fmt.Errorf("error compiling headerRegex %q for header %q: %w", pattern, headerName, compileError)
+			}
+
+			headerRegex[headerName] = compiledHeaderRegex
+		}
+
+		compiledRules[index] = &compiledRule{
+			regex:             compiledRegex,
+			methods:           methods,
+			queryRegex:        queryRegex,
+			headerRegex:       headerRegex,
+			minBodyBytes:      rule.MinBodyBytes,
+			statusCode:        statusCode,
+			responseBody:      rule.ResponseBody,
+			contentType:       rule.ContentType,
+			redirectTo:        rule.RedirectTo,
+			permanent:         rule.Permanent,
+			metricRegexLabel:  rule.PathRegex,
+			metricStatusLabel: strconv.Itoa(statusCode),
+		}
+	}
+
+	privateRangeValues := config.PrivateRanges
+	if len(privateRangeValues) == 0 {
+		privateRangeValues = defaultPrivateRanges
+	}
+
+	privateRanges, err := parseCIDRList(privateRangeValues)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing privateRanges: %w", err)
+	}
+
+	trustedProxies, err := parseCIDRList(config.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing trustedProxies: %w", err)
+	}
+
+	banMaxHits, banWindow, banDuration, banStatusCode, err := parseBanConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsPath := config.Metrics.Path
+	if metricsPath == "" {
+		metricsPath = defaultMetricsPath
 	}
 
 	return &traefik_block_paths{
 		next:               next,
 		name:               name,
-		regexps:            regexps,
+		rules:              compiledRules,
+		allowLocalRequests: config.AllowLocalRequests,
+		privateRanges:      privateRanges,
+		trustedProxies:     trustedProxies,
 		silentStartUp:      config.SilentStartUp,
 		statusCode:         config.StatusCode,
+		banEnabled:         config.Ban.Enabled,
+		banMaxHits:         banMaxHits,
+		banWindow:          banWindow,
+		banDuration:        banDuration,
+		banStatusCode:      banStatusCode,
+		bans:               make(map[string]*banEntry),
+		metricsEnabled:     config.Metrics.Enabled,
+		metricsPath:        metricsPath,
+		metricsIncludeHost: config.Metrics.IncludeHostLabel,
 	}, nil
 }
 
 // This method is the middleware called during runtime and handling middleware actions.
 func (blockPaths *traefik_block_paths) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
-	currentPath := request.URL.EscapedPath()
-
-	for _, regex := range blockPaths.regexps {
-		if regex.MatchString(currentPath) {
-			ipAddresses, err := blockPaths.CollectRemoteIP(request)
-			if err != nil {
-				log.Println("Failed to collect remote ip...")
-				log.Println(err)
-			}
-		
-			log.Printf("%s: Request (%s %s) denied for IPs [%s]", blockPaths.name, request.Host, request.URL, ipAddresses)
+	if blockPaths.metricsEnabled && request.URL.Path == blockPaths.metricsPath {
+		blockPaths.writeMetrics(responseWriter)
+		return
+	}
+
+	clientIP := blockPaths.resolveClientIP(request)
+	clientKey := ""
+	if clientIP != nil {
+		clientKey = clientIP.String()
+	}
+
+	if blockPaths.banEnabled && clientKey != "" && blockPaths.isBanned(clientKey) {
+		responseWriter.WriteHeader(blockPaths.banStatusCode)
+		return
+	}
+
+	for _, rule := range blockPaths.rules {
+		if !rule.matches(request) {
+			continue
+		}
 
-			responseWriter.WriteHeader(blockPaths.statusCode)
-			return
+		if blockPaths.allowLocalRequests && blockPaths.isPrivate(clientIP) {
+			break
 		}
+
+		log.Printf("%s: Request (%s %s) denied for IP [%s]", blockPaths.name, request.Host, request.URL, clientKey)
+
+		if blockPaths.banEnabled && clientKey != "" {
+			blockPaths.recordHit(clientKey)
+		}
+
+		rule.recordBlock(request.Host, blockPaths.metricsIncludeHost)
+		rule.respond(responseWriter)
+		return
 	}
 
+	atomic.AddInt64(&blockPaths.allowedCount, 1)
 	blockPaths.next.ServeHTTP(responseWriter, request)
 }
 
@@ -99,12 +322,287 @@ func (blockPaths *traefik_block_paths) ServeHTTP(responseWriter http.ResponseWri
  *         Private methods        *
  **********************************/
 
-// This method collects the remote IP address.
-// It tries to parse the IP from the HTTP request.
-// Returns the parsed IP and no error on success, otherwise the so far generated list and an error.
-func (blockPaths *traefik_block_paths) CollectRemoteIP(request *http.Request) ([]*net.IP, error) {
-	var ipList []*net.IP
+// This method validates and extracts the ban settings, defaulting StatusCode to the
+// global Config.StatusCode. Returns zero values when banning is disabled.
+func parseBanConfig(config *Config) (int, time.Duration, time.Duration, int, error) {
+	if !config.Ban.Enabled {
+		return 0, 0, 0, 0, nil
+	}
+
+	if config.Ban.MaxHits <= 0 {
+		return 0, 0, 0, 0, fmt.Errorf("ban.maxHits must be greater than 0 when ban is enabled")
+	}
+
+	if config.Ban.Window <= 0 {
+		return 0, 0, 0, 0, fmt.Errorf("ban.window must be greater than 0 when ban is enabled")
+	}
+
+	if config.Ban.Duration <= 0 {
+		return 0, 0, 0, 0, fmt.Errorf("ban.duration must be greater than 0 when ban is enabled")
+	}
+
+	statusCode := config.Ban.StatusCode
+	if statusCode == 0 {
+		statusCode = config.StatusCode
+	}
+
+	return config.Ban.MaxHits, config.Ban.Window, config.Ban.Duration, statusCode, nil
+}
+
+// This method checks whether the client is currently banned, evicting the entry if its ban expired.
+// Returns true if the client is banned.
+func (blockPaths *traefik_block_paths) isBanned(clientKey string) bool {
+	blockPaths.banMutex.Lock()
+	defer blockPaths.banMutex.Unlock()
+
+	entry, ok := blockPaths.bans[clientKey]
+	if !ok || entry.bannedUntil.IsZero() {
+		return false
+	}
+
+	if time.Now().After(entry.bannedUntil) {
+		delete(blockPaths.bans, clientKey)
+		return false
+	}
+
+	return true
+}
+
+// This method records a block hit for the client and bans it once MaxHits is reached
+// inside Window (the MaxHits-th hit itself is still answered by the rule). Expired entries are evicted lazily on access so the map doesn't grow unbounded.
+func (blockPaths *traefik_block_paths) recordHit(clientKey string) {
+	blockPaths.banMutex.Lock()
+	defer blockPaths.banMutex.Unlock()
+
+	now := time.Now()
+	blockPaths.evictExpiredLocked(now)
+
+	entry, ok := blockPaths.bans[clientKey]
+	if !ok {
+		entry = &banEntry{}
+		blockPaths.bans[clientKey] = entry
+	}
+
+	windowStart := now.Add(-blockPaths.banWindow)
+	freshHits := entry.hits[:0]
+	for _, hit := range entry.hits {
+		if hit.After(windowStart) {
+			freshHits = append(freshHits, hit)
+		}
+	}
+	entry.hits = append(freshHits, now)
+
+	if len(entry.hits) >= blockPaths.banMaxHits {
+		entry.bannedUntil = now.Add(blockPaths.banDuration)
+		entry.hits = nil
+		atomic.AddInt64(&blockPaths.banEventsCount, 1)
+	}
+}
+
+// This method counts the currently active bans, evicting expired entries along the way.
+func (blockPaths *traefik_block_paths) activeBans() int64 {
+	blockPaths.banMutex.Lock()
+	defer blockPaths.banMutex.Unlock()
+
+	blockPaths.evictExpiredLocked(time.Now())
+
+	var count int64
+	for _, entry := range blockPaths.bans {
+		if !entry.bannedUntil.IsZero() {
+			count++
+		}
+	}
+
+	return count
+}
+
+// This method evicts entries whose ban has expired or whose hits have all aged out of Window.
+// The caller must hold banMutex.
+func (blockPaths *traefik_block_paths) evictExpiredLocked(now time.Time) {
+	windowStart := now.Add(-blockPaths.banWindow)
+
+	for key, entry := range blockPaths.bans {
+		if !entry.bannedUntil.IsZero() {
+			if now.After(entry.bannedUntil) {
+				delete(blockPaths.bans, key)
+			}
+			continue
+		}
+
+		stale := true
+		for _, hit := range entry.hits {
+			if hit.After(windowStart) {
+				stale = false
+				break
+			}
+		}
+
+		if stale {
+			delete(blockPaths.bans, key)
+		}
+	}
+}
+
+// This method builds the effective rule list, translating the legacy top-level
+// Regex + StatusCode fields into one Rule per entry for backward compatibility.
+// Returns the combined rule list.
+func synthesizeRules(config *Config) []Rule {
+	rules := make([]Rule, 0, len(config.Regex)+len(config.Rules))
+
+	for _, regex := range config.Regex {
+		rules = append(rules, Rule{Matcher: Matcher{PathRegex: regex}, StatusCode: config.StatusCode})
+	}
+
+	rules = append(rules, config.Rules...)
+
+	return rules
+}
+
+// This method checks whether the request satisfies the rule: the path regex plus every
+// populated matcher field (methods, query, headers, minimum body size), combined with AND
+// semantics. The body itself is never read, only Content-Length.
+// Returns true if the rule applies.
+func (rule *compiledRule) matches(request *http.Request) bool {
+	if !rule.regex.MatchString(request.URL.EscapedPath()) {
+		return false
+	}
+
+	if len(rule.methods) > 0 {
+		if _, ok := rule.methods[strings.ToUpper(request.Method)]; !ok {
+			return false
+		}
+	}
+
+	if rule.queryRegex != nil && !rule.queryRegex.MatchString(request.URL.RawQuery) {
+		return false
+	}
+
+	for headerName, headerRegex := range rule.headerRegex {
+		if !headerRegex.MatchString(request.Header.Get(headerName)) {
+			return false
+		}
+	}
+
+	if rule.minBodyBytes > 0 && request.ContentLength < rule.minBodyBytes {
+		return false
+	}
+
+	return true
+}
+
+// This method records a block against the rule's counters: a global count, and, when
+// IncludeHostLabel is enabled, a per-host count (kept separate due to the cardinality risk).
+func (rule *compiledRule) recordBlock(host string, includeHostLabel bool) {
+	atomic.AddInt64(&rule.blockedCount, 1)
+
+	if !includeHostLabel {
+		return
+	}
+
+	counter, _ := rule.blockedByHost.LoadOrStore(host, new(int64))
+	atomic.AddInt64(counter.(*int64), 1)
+}
+
+// This method writes this rule's blockpaths_requests_blocked_total samples in Prometheus text format.
+func (rule *compiledRule) writeBlockedSamples(builder *strings.Builder, includeHostLabel bool) {
+	if !includeHostLabel {
+		fmt.Fprintf(builder, "blockpaths_requests_blocked_total{regex=%q,status=%q} %d\n", rule.metricRegexLabel, rule.metricStatusLabel, atomic.LoadInt64(&rule.blockedCount))
+		return
+	}
+
+	rule.blockedByHost.Range(func(key, value interface{}) bool {
+		fmt.Fprintf(builder, "blockpaths_requests_blocked_total{regex=%q,status=%q,host=%q} %d\n", rule.metricRegexLabel, rule.metricStatusLabel, key.(string), atomic.LoadInt64(value.(*int64)))
+		return true
+	})
+}
+
+// This method writes the rule's configured response: a redirect, a custom body, or just the status code.
+func (rule *compiledRule) respond(responseWriter http.ResponseWriter) {
+	if rule.redirectTo != "" {
+		statusCode := http.StatusPermanentRedirect
+		if rule.permanent {
+			statusCode = http.StatusMovedPermanently
+		}
+
+		responseWriter.Header().Set("Location", rule.redirectTo)
+		responseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	if rule.responseBody != "" {
+		contentType := rule.contentType
+		if contentType == "" {
+			contentType = "text/html; charset=utf-8"
+		}
+
+		responseWriter.Header().Set("Content-Type", contentType)
+		responseWriter.WriteHeader(rule.statusCode)
+		responseWriter.Write([]byte(rule.responseBody))
+		return
+	}
+
+	responseWriter.WriteHeader(rule.statusCode)
+}
+
+// This method writes the plugin's counters and gauges in Prometheus text format 0.0.4.
+func (blockPaths *traefik_block_paths) writeMetrics(responseWriter http.ResponseWriter) {
+	responseWriter.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	var builder strings.Builder
+
+	builder.WriteString("# HELP blockpaths_requests_blocked_total Total number of requests blocked by a rule.\n")
+	builder.WriteString("# TYPE blockpaths_requests_blocked_total counter\n")
+	for _, rule := range blockPaths.rules {
+		rule.writeBlockedSamples(&builder, blockPaths.metricsIncludeHost)
+	}
+
+	builder.WriteString("# HELP blockpaths_requests_allowed_total Total number of requests that passed through unblocked.\n")
+	builder.WriteString("# TYPE blockpaths_requests_allowed_total counter\n")
+	fmt.Fprintf(&builder, "blockpaths_requests_allowed_total %d\n", atomic.LoadInt64(&blockPaths.allowedCount))
 
+	builder.WriteString("# HELP blockpaths_ban_active Number of client IPs currently banned.\n")
+	builder.WriteString("# TYPE blockpaths_ban_active gauge\n")
+	fmt.Fprintf(&builder, "blockpaths_ban_active %d\n", blockPaths.activeBans())
+
+	builder.WriteString("# HELP blockpaths_ban_events_total Total number of times a client crossed the ban threshold.\n")
+	builder.WriteString("# TYPE blockpaths_ban_events_total counter\n")
+	fmt.Fprintf(&builder, "blockpaths_ban_events_total %d\n", atomic.LoadInt64(&blockPaths.banEventsCount))
+
+	responseWriter.Write([]byte(builder.String()))
+}
+
+// This method resolves the single client IP to evaluate for the local-request bypass and
+// ban tracking. It only honors X-Forwarded-For / X-Real-IP when request.RemoteAddr is a
+// trusted proxy, otherwise RemoteAddr itself is the sole client identity. When trusted,
+// only the right-most (most recently appended) entry is used: that is the IP the trusted
+// hop itself observed and appended, so it cannot be spoofed by an attacker prefixing extra
+// entries onto the header. Returns nil when no IP could be resolved.
+func (blockPaths *traefik_block_paths) resolveClientIP(request *http.Request) *net.IP {
+	remoteIP := parseRemoteAddr(request.RemoteAddr)
+
+	if remoteIP == nil || !blockPaths.isTrustedProxy(*remoteIP) {
+		return remoteIP
+	}
+
+	forwardedIP, err := blockPaths.CollectRemoteIP(request)
+	if err != nil {
+		log.Println("Failed to collect remote ip...")
+		log.Println(err)
+	}
+
+	if forwardedIP == nil {
+		return remoteIP
+	}
+
+	return forwardedIP
+}
+
+// This method collects the remote IP address as appended by the trusted proxy.
+// It tries X-Forwarded-For first, taking its right-most entry (the IP the nearest proxy
+// appended), falling back to the right-most entry of X-Real-IP.
+// Returns the parsed IP and no error on success, nil and no error when neither header is
+// present, or nil and an error when a present header fails to parse.
+func (blockPaths *traefik_block_paths) CollectRemoteIP(request *http.Request) (*net.IP, error) {
 	// Helper method to split a string at char ','
 	splitFn := func(c rune) bool {
 		return c == ','
@@ -113,28 +611,87 @@ func (blockPaths *traefik_block_paths) CollectRemoteIP(request *http.Request) ([
 	// Try to parse from header "X-Forwarded-For"
 	xForwardedForValue := request.Header.Get("X-Forwarded-For")
 	xForwardedForIPs := strings.FieldsFunc(xForwardedForValue, splitFn)
-	for _, value := range xForwardedForIPs {
-		ipAddress, err := ParseIP(value)
+	if len(xForwardedForIPs) > 0 {
+		ipAddress, err := ParseIP(strings.TrimSpace(xForwardedForIPs[len(xForwardedForIPs)-1]))
 		if err != nil {
-			return ipList, fmt.Errorf("parsing failed: %s", err)
+			return nil, fmt.Errorf("parsing failed: %s", err)
 		}
 
-		ipList = append(ipList, &ipAddress)
+		return &ipAddress, nil
 	}
 
 	// Try to parse from header "X-Real-IP"
 	xRealIpValue := request.Header.Get("X-Real-IP")
 	xRealIpIPs := strings.FieldsFunc(xRealIpValue, splitFn)
-	for _, value := range xRealIpIPs {
-		ipAddress, err := ParseIP(value)
+	if len(xRealIpIPs) > 0 {
+		ipAddress, err := ParseIP(strings.TrimSpace(xRealIpIPs[len(xRealIpIPs)-1]))
 		if err != nil {
-			return ipList, fmt.Errorf("parsing failed: %s", err)
+			return nil, fmt.Errorf("parsing failed: %s", err)
 		}
 
-		ipList = append(ipList, &ipAddress)
+		return &ipAddress, nil
+	}
+
+	return nil, nil
+}
+
+// This method checks whether the given IP falls inside any of the configured trusted proxy ranges.
+// Returns true if the IP is trusted, otherwise false.
+func (blockPaths *traefik_block_paths) isTrustedProxy(ip net.IP) bool {
+	return ipInRanges(ip, blockPaths.trustedProxies)
+}
+
+// This method checks whether the given IP falls inside the configured private ranges.
+// Returns true if the IP is private, otherwise false.
+func (blockPaths *traefik_block_paths) isPrivate(ip *net.IP) bool {
+	return ip != nil && ipInRanges(*ip, blockPaths.privateRanges)
+}
+
+// This method checks whether the given IP is contained in any of the provided networks.
+// Returns true if it is contained in at least one network, otherwise false.
+func ipInRanges(ip net.IP, ranges []*net.IPNet) bool {
+	for _, ipNet := range ranges {
+		if ipNet.Contains(ip) {
+			return true
+		}
 	}
 
-	return ipList, nil
+	return false
+}
+
+// This method parses a list of CIDR blocks (or single IPs, treated as /32 or /128) into IP networks.
+// Returns the parsed networks and no error on success, otherwise nil and the occured error.
+func parseCIDRList(values []string) ([]*net.IPNet, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	ipNets := make([]*net.IPNet, 0, len(values))
+
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ipAddress := net.ParseIP(value)
+			if ipAddress == nil {
+				return nil, fmt.Errorf("unable to parse IP or CIDR from %q", value)
+			}
+
+			bits := 32
+			if ipAddress.To4() == nil {
+				bits = 128
+			}
+
+			value = fmt.Sprintf("%s/%d", value, bits)
+		}
+
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse CIDR %q: %w", value, err)
+		}
+
+		ipNets = append(ipNets, ipNet)
+	}
+
+	return ipNets, nil
 }
 
 // Tries to parse the IP from a provided address.
@@ -148,3 +705,23 @@ func ParseIP(address string) (net.IP, error) {
 
 	return ipAddress, nil
 }
+
+// This method parses the IP part out of a RemoteAddr value ("host:port" or bare IP).
+// Returns the parsed IP, or nil if it could not be parsed.
+func parseRemoteAddr(remoteAddr string) *net.IP {
+	if remoteAddr == "" {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ipAddress := net.ParseIP(host)
+	if ipAddress == nil {
+		return nil
+	}
+
+	return &ipAddress
+}
@@ -2,9 +2,12 @@ package traefik_block_paths_test
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	BlockPaths "github.com/JonasSchubert/traefik-block-paths"
 )
@@ -30,6 +33,7 @@ func Test_BlockPaths_ReturnsBlock_IfMatched(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	req.RemoteAddr = "203.0.113.5:12345"
 	req.Header.Add("X-Forwarded-For", "2.56.20.0")
 
 	handler.ServeHTTP(recorder, req)
@@ -58,6 +62,7 @@ func Test_BlockPaths_ReturnsOK_IfNotMatched(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	req.RemoteAddr = "203.0.113.5:12345"
 	req.Header.Add("X-Forwarded-For", "2.56.20.0")
 
 	handler.ServeHTTP(recorder, req)
@@ -70,6 +75,7 @@ func Test_BlockPaths_ReturnsOK_IfMatched_ButLocalIpIsAllowed(t *testing.T) {
 
 	cfg.Regex = []string{"^/wp(.*)"}
 	cfg.StatusCode = 404
+	cfg.TrustedProxies = []string{"203.0.113.5/32"}
 
 	ctx := context.Background()
 	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
@@ -86,6 +92,7 @@ func Test_BlockPaths_ReturnsOK_IfMatched_ButLocalIpIsAllowed(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	req.RemoteAddr = "203.0.113.5:12345"
 	req.Header.Add("X-Real-IP", "192.168.1.1")
 
 	handler.ServeHTTP(recorder, req)
@@ -93,10 +100,72 @@ func Test_BlockPaths_ReturnsOK_IfMatched_ButLocalIpIsAllowed(t *testing.T) {
 	assertStatusCode(t, recorder.Result(), http.StatusOK)
 }
 
+func Test_BlockPaths_ReturnsBlock_IfMatched_AndSpoofedPrivateIpIsPrefixedOntoForwardedFor(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Regex = []string{"^/wp(.*)"}
+	cfg.StatusCode = 404
+	cfg.TrustedProxies = []string{"203.0.113.5/32"}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/wp-login", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+	// The attacker prefixes a spoofed private IP onto X-Forwarded-For; only the right-most
+	// entry (the one the trusted proxy actually appended) may be treated as the client.
+	req.Header.Add("X-Forwarded-For", "127.0.0.1, 203.0.113.99")
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
+}
+
 func Test_BlockPaths_ReturnsBlock_IfMatched_AndLocalIpIsNotAllowed(t *testing.T) {
 	cfg := BlockPaths.CreateConfig()
 
 	cfg.AllowLocalRequests = false
+	cfg.Regex = []string{"^/wp(.*)"}
+	cfg.StatusCode = 404
+	cfg.TrustedProxies = []string{"203.0.113.5/32"}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/wp-login", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+	req.Header.Add("X-Real-IP", "192.168.1.1")
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
+}
+
+func Test_BlockPaths_ReturnsBlock_IfMatched_AndSpoofedLocalIpFromUntrustedSourceIsIgnored(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
 	cfg.Regex = []string{"^/wp(.*)"}
 	cfg.StatusCode = 404
 
@@ -115,6 +184,8 @@ func Test_BlockPaths_ReturnsBlock_IfMatched_AndLocalIpIsNotAllowed(t *testing.T)
 		t.Fatal(err)
 	}
 
+	// RemoteAddr is not in TrustedProxies, so the spoofed X-Real-IP claiming a private address must be ignored.
+	req.RemoteAddr = "203.0.113.5:12345"
 	req.Header.Add("X-Real-IP", "192.168.1.1")
 
 	handler.ServeHTTP(recorder, req)
@@ -122,6 +193,527 @@ func Test_BlockPaths_ReturnsBlock_IfMatched_AndLocalIpIsNotAllowed(t *testing.T)
 	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
 }
 
+func Test_BlockPaths_ReturnsOK_IfMatched_AndRemoteAddrItselfIsPrivate(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Regex = []string{"^/wp(.*)"}
+	cfg.StatusCode = 404
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/wp-login", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "192.168.1.1:12345"
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusOK)
+}
+
+func Test_BlockPaths_ReturnsBlock_IfRuleMethodMatches(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Rules = []BlockPaths.Rule{
+		{Matcher: BlockPaths.Matcher{PathRegex: "^/xmlrpc\\.php$", Methods: []string{"POST"}}, StatusCode: 405},
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost/xmlrpc.php", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusMethodNotAllowed)
+}
+
+func Test_BlockPaths_ReturnsOK_IfRuleMethodDoesNotMatch(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Rules = []BlockPaths.Rule{
+		{Matcher: BlockPaths.Matcher{PathRegex: "^/xmlrpc\\.php$", Methods: []string{"POST"}}, StatusCode: 405},
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/xmlrpc.php", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusOK)
+}
+
+func Test_BlockPaths_ReturnsCustomBody_IfRuleHasResponseBody(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Rules = []BlockPaths.Rule{
+		{Matcher: BlockPaths.Matcher{PathRegex: "^/wp(.*)"}, StatusCode: 410, ResponseBody: "<h1>Gone</h1>", ContentType: "text/html"},
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/wp-login", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+
+	handler.ServeHTTP(recorder, req)
+
+	result := recorder.Result()
+	assertStatusCode(t, result, http.StatusGone)
+
+	if contentType := result.Header.Get("Content-Type"); contentType != "text/html" {
+		t.Errorf("invalid content type: %s", contentType)
+	}
+}
+
+func Test_BlockPaths_ReturnsRedirect_IfRuleHasRedirectTo(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Rules = []BlockPaths.Rule{
+		{Matcher: BlockPaths.Matcher{PathRegex: "^/old-admin$"}, RedirectTo: "/admin"},
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/old-admin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+
+	handler.ServeHTTP(recorder, req)
+
+	result := recorder.Result()
+	assertStatusCode(t, result, http.StatusPermanentRedirect)
+
+	if location := result.Header.Get("Location"); location != "/admin" {
+		t.Errorf("invalid redirect location: %s", location)
+	}
+}
+
+func Test_BlockPaths_ReturnsBlock_IfLegacyRegexAndStatusCodeAreSynthesizedIntoRule(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Regex = []string{"^/wp(.*)"}
+	cfg.StatusCode = 404
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/wp-login", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
+}
+
+func Test_BlockPaths_Ban_BansClient_AfterMaxHitsCrossed(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Regex = []string{"^/wp(.*)"}
+	cfg.StatusCode = 404
+	cfg.Ban = BlockPaths.BanConfig{
+		Enabled:    true,
+		MaxHits:    2,
+		Window:     time.Minute,
+		Duration:   time.Minute,
+		StatusCode: 429,
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func() *http.Request {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/wp-login", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = "203.0.113.9:12345"
+		return req
+	}
+
+	// First hit is blocked normally, without yet crossing MaxHits.
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest())
+	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
+
+	// The second hit crosses MaxHits, still gets blocked, but bans the client for what follows.
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest())
+	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest())
+	assertStatusCode(t, recorder.Result(), http.StatusTooManyRequests)
+}
+
+func Test_BlockPaths_Ban_KeysOnTrustedDerivedIp_IgnoringSpoofedForwardedForPrefix(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Regex = []string{"^/wp(.*)"}
+	cfg.StatusCode = 404
+	cfg.TrustedProxies = []string{"203.0.113.5/32"}
+	cfg.Ban = BlockPaths.BanConfig{
+		Enabled:    true,
+		MaxHits:    2,
+		Window:     time.Minute,
+		Duration:   time.Minute,
+		StatusCode: 429,
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every request comes through the same trusted proxy from the same real attacker IP, but
+	// varies the spoofed leading X-Forwarded-For entry. Since only the right-most entry (the
+	// one the trusted proxy appended) is used as the ban key, the attacker cannot evade MaxHits.
+	newRequest := func(spoofedPrefix string) *http.Request {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/wp-login", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = "203.0.113.5:12345"
+		req.Header.Add("X-Forwarded-For", spoofedPrefix+", 203.0.113.99")
+		return req
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest("127.0.0.1"))
+	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest("10.0.0.1"))
+	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest("172.16.0.1"))
+	assertStatusCode(t, recorder.Result(), http.StatusTooManyRequests)
+}
+
+func Test_BlockPaths_Ban_ExpiresAfterDuration(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Regex = []string{"^/wp(.*)"}
+	cfg.StatusCode = 404
+	cfg.Ban = BlockPaths.BanConfig{
+		Enabled:    true,
+		MaxHits:    1,
+		Window:     time.Minute,
+		Duration:   20 * time.Millisecond,
+		StatusCode: 429,
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func() *http.Request {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/wp-login", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.RemoteAddr = "203.0.113.10:12345"
+		return req
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest())
+	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest())
+	assertStatusCode(t, recorder.Result(), http.StatusTooManyRequests)
+
+	time.Sleep(30 * time.Millisecond)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newRequest())
+	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
+}
+
+func Test_BlockPaths_Metrics_ScrapeEndpoint_ReportsCounters(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Regex = []string{"^/wp(.*)"}
+	cfg.StatusCode = 404
+	cfg.Metrics = BlockPaths.MetricsConfig{Enabled: true}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blockedReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/wp-login", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockedReq.RemoteAddr = "203.0.113.5:12345"
+	handler.ServeHTTP(httptest.NewRecorder(), blockedReq)
+
+	allowedReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/index.html", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowedReq.RemoteAddr = "203.0.113.5:12345"
+	handler.ServeHTTP(httptest.NewRecorder(), allowedReq)
+
+	metricsReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/__blockpaths/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, metricsReq)
+
+	assertStatusCode(t, recorder.Result(), http.StatusOK)
+
+	body, err := io.ReadAll(recorder.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(body), `blockpaths_requests_blocked_total{regex="^/wp(.*)",status="404"} 1`) {
+		t.Errorf("expected blocked counter sample, got:\n%s", body)
+	}
+
+	if !strings.Contains(string(body), "blockpaths_requests_allowed_total 1") {
+		t.Errorf("expected allowed counter sample, got:\n%s", body)
+	}
+}
+
+func Test_BlockPaths_ReturnsBlock_IfQueryRegexMatches(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Rules = []BlockPaths.Rule{
+		{Matcher: BlockPaths.Matcher{PathRegex: "^/", QueryRegex: "XDEBUG_SESSION_START"}, StatusCode: 403},
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/index.php?XDEBUG_SESSION_START=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusForbidden)
+}
+
+func Test_BlockPaths_ReturnsOK_IfQueryRegexDoesNotMatch(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Rules = []BlockPaths.Rule{
+		{Matcher: BlockPaths.Matcher{PathRegex: "^/", QueryRegex: "XDEBUG_SESSION_START"}, StatusCode: 403},
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/index.php?foo=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusOK)
+}
+
+func Test_BlockPaths_ReturnsBlock_IfHeaderRegexMatches(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Rules = []BlockPaths.Rule{
+		{Matcher: BlockPaths.Matcher{PathRegex: "^/", HeaderRegex: map[string]string{"User-Agent": "(?i)zmeu"}}, StatusCode: 403},
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+	req.Header.Set("User-Agent", "ZmEu")
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusForbidden)
+}
+
+func Test_BlockPaths_ReturnsBlock_IfMinBodyBytesExceeded(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Rules = []BlockPaths.Rule{
+		{Matcher: BlockPaths.Matcher{PathRegex: "^/api", Methods: []string{"POST"}, MinBodyBytes: 1024 * 1024}, StatusCode: 413},
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost/api/upload", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+	req.ContentLength = 2 * 1024 * 1024
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusRequestEntityTooLarge)
+}
+
+func Test_BlockPaths_ReturnsOK_IfMinBodyBytesNotExceeded(t *testing.T) {
+	cfg := BlockPaths.CreateConfig()
+
+	cfg.Rules = []BlockPaths.Rule{
+		{Matcher: BlockPaths.Matcher{PathRegex: "^/api", Methods: []string{"POST"}, MinBodyBytes: 1024 * 1024}, StatusCode: 413},
+	}
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockPaths.New(ctx, next, cfg, "BlockPaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost/api/upload", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.RemoteAddr = "203.0.113.5:12345"
+	req.ContentLength = 1024
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusOK)
+}
+
 func assertStatusCode(t *testing.T, req *http.Response, expected int) {
 	t.Helper()
 